@@ -0,0 +1,66 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// cancelAfterInput cancels cancel once n entries have been read, to
+// simulate a slow Input whose caller cancels mid-generation.
+type cancelAfterInput struct {
+	sliceInput
+	n      int
+	cancel context.CancelFunc
+}
+
+func (in *cancelAfterInput) Next() *UrlEntry {
+	e := in.sliceInput.Next()
+	in.n--
+	if in.n == 0 {
+		in.cancel()
+	}
+	return e
+}
+
+func TestWriteAllContextCancellation(t *testing.T) {
+	entries := make([]*UrlEntry, 10)
+	for i := range entries {
+		entries[i] = &UrlEntry{Loc: "http://example.com/x"}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := &cancelAfterInput{sliceInput: sliceInput{entries: entries}, n: 3, cancel: cancel}
+
+	err := WriteAllContext(ctx, &bufOutput{}, in)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("WriteAllContext error = %v, want context.Canceled", err)
+	}
+}
+
+func TestUrlEntryValidateTypedError(t *testing.T) {
+	entries := []*UrlEntry{
+		{Loc: "http://example.com/ok"},
+		{Loc: ""}, // invalid: ErrInvalidLoc
+	}
+
+	err := WriteAll(&bufOutput{}, &sliceInput{entries: entries})
+	if err == nil {
+		t.Fatal("WriteAll error = nil, want a validation error")
+	}
+
+	var sErr *Error
+	if !errors.As(err, &sErr) {
+		t.Fatalf("error %v is not a *sitemap.Error", err)
+	}
+	if sErr.Op != OpValidateEntry {
+		t.Errorf("Op = %q, want %q", sErr.Op, OpValidateEntry)
+	}
+	if sErr.EntryIndex != 1 {
+		t.Errorf("EntryIndex = %d, want 1", sErr.EntryIndex)
+	}
+	if !errors.Is(err, ErrInvalidLoc) {
+		t.Errorf("error %v does not wrap ErrInvalidLoc", err)
+	}
+}
@@ -0,0 +1,133 @@
+package sitemap
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors returned by UrlEntry.Validate.
+var (
+	ErrInvalidLoc        = errors.New("sitemap: loc is required")
+	ErrInvalidPriority   = errors.New("sitemap: priority must be between 0.0 and 1.0")
+	ErrInvalidChangeFreq = errors.New("sitemap: invalid changefreq value")
+	ErrInvalidImageLoc   = errors.New("sitemap: image loc is required")
+	ErrTooManyImages     = errors.New("sitemap: too many images for a single url")
+	ErrInvalidHrefLang   = errors.New("sitemap: hreflang entry requires lang and href")
+)
+
+// maxImagesPerEntry is the sitemaps.org image extension's limit on the
+// number of images listed for a single URL.
+const maxImagesPerEntry = 1000
+
+// ChangeFreq is a hint to crawlers about how frequently a page is likely to
+// change. See UrlEntry.ChangeFreq.
+type ChangeFreq string
+
+// Valid ChangeFreq values, as defined by the sitemaps.org spec.
+const (
+	ChangeFreqAlways  ChangeFreq = "always"
+	ChangeFreqHourly  ChangeFreq = "hourly"
+	ChangeFreqDaily   ChangeFreq = "daily"
+	ChangeFreqWeekly  ChangeFreq = "weekly"
+	ChangeFreqMonthly ChangeFreq = "monthly"
+	ChangeFreqYearly  ChangeFreq = "yearly"
+	ChangeFreqNever   ChangeFreq = "never"
+)
+
+func (f ChangeFreq) valid() bool {
+	switch f {
+	case "", ChangeFreqAlways, ChangeFreqHourly, ChangeFreqDaily, ChangeFreqWeekly, ChangeFreqMonthly, ChangeFreqYearly, ChangeFreqNever:
+		return true
+	}
+	return false
+}
+
+// Image is a single <image:image> entry associated with a UrlEntry.
+type Image struct {
+	// Loc is the URL of the image. Required.
+	Loc string
+
+	// Title is the title of the image. Optional.
+	Title string
+
+	// Caption is a caption describing the image. Optional.
+	Caption string
+
+	// License is the URL of the image's license. Optional.
+	License string
+
+	// GeoLocation is the geographic location the image was taken at, e.g.
+	// "Seoul, South Korea". Optional.
+	GeoLocation string
+}
+
+// HrefLang is a single <xhtml:link rel="alternate"> entry, pointing to a
+// translated version of the page for a given language or locale.
+type HrefLang struct {
+	// Lang is the language/locale of the alternate page, e.g. "en",
+	// "en-US" or "x-default".
+	Lang string
+
+	// Href is the URL of the alternate page.
+	Href string
+}
+
+// UrlEntry is a single <url> entry in a sitemap urlset file.
+type UrlEntry struct {
+	// Loc is the URL of the page. Required.
+	Loc string
+
+	// LastMod is the last modification date of the page. It is omitted
+	// from the output when it is before minDate.
+	LastMod time.Time
+
+	// ChangeFreq is a hint to crawlers about how frequently the page is
+	// likely to change. Zero value omits the tag.
+	ChangeFreq ChangeFreq
+
+	// Priority is the priority of the page relative to other pages, from
+	// 0.0 to 1.0. Zero omits the tag, letting crawlers apply their own
+	// default.
+	Priority float64
+
+	// Images are the images associated with the page.
+	Images []Image
+
+	// Videos are the videos associated with the page.
+	Videos []Video
+
+	// HrefLangs are alternate, translated versions of the page.
+	HrefLangs []HrefLang
+}
+
+// Validate reports whether e can be serialized as a valid sitemap entry.
+func (e *UrlEntry) Validate() error {
+	if e.Loc == "" {
+		return ErrInvalidLoc
+	}
+	if e.Priority != 0 && (e.Priority < 0 || e.Priority > 1) {
+		return ErrInvalidPriority
+	}
+	if !e.ChangeFreq.valid() {
+		return ErrInvalidChangeFreq
+	}
+	if len(e.Images) > maxImagesPerEntry {
+		return ErrTooManyImages
+	}
+	for i := range e.Images {
+		if e.Images[i].Loc == "" {
+			return ErrInvalidImageLoc
+		}
+	}
+	for i := range e.Videos {
+		if err := e.Videos[i].validate(); err != nil {
+			return err
+		}
+	}
+	for i := range e.HrefLangs {
+		if e.HrefLangs[i].Lang == "" || e.HrefLangs[i].Href == "" {
+			return ErrInvalidHrefLang
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sitemap
+
+import "fmt"
+
+// Op identifies the operation an Error occurred during.
+type Op string
+
+// Values of Op returned in Error.
+const (
+	OpWriteUrlset   Op = "write-urlset"
+	OpWriteIndex    Op = "write-index"
+	OpValidateEntry Op = "validate-entry"
+)
+
+// Error carries context about where and why a WriteAll/WriteAllOptions call
+// failed, so callers can tell a failed write (Op OpWriteUrlset or
+// OpWriteIndex) from an invalid entry (Op OpValidateEntry) apart, and match
+// the cause via errors.Is/errors.As against Err.
+type Error struct {
+	// Op is the operation that failed.
+	Op Op
+
+	// FileIndex is the 0-based index of the urlset file being written when
+	// the error occurred, or -1 if not applicable (e.g. for the index
+	// file).
+	FileIndex int
+
+	// EntryIndex is the 0-based index of the entry within FileIndex being
+	// written when the error occurred, or -1 if not applicable.
+	EntryIndex int
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.FileIndex >= 0 && e.EntryIndex >= 0:
+		return fmt.Sprintf("sitemap: %s: file %d, entry %d: %v", e.Op, e.FileIndex, e.EntryIndex, e.Err)
+	case e.FileIndex >= 0:
+		return fmt.Sprintf("sitemap: %s: file %d: %v", e.Op, e.FileIndex, e.Err)
+	default:
+		return fmt.Sprintf("sitemap: %s: %v", e.Op, e.Err)
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
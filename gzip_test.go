@@ -0,0 +1,112 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type sliceInput struct {
+	entries []*UrlEntry
+	i       int
+}
+
+func (in *sliceInput) HasNext() bool { return in.i < len(in.entries) }
+
+func (in *sliceInput) Next() *UrlEntry {
+	e := in.entries[in.i]
+	in.i++
+	return e
+}
+
+func (in *sliceInput) GetUrlsetUrl(i int) string {
+	return fmt.Sprintf("http://example.com/sitemap%d.xml.gz", i)
+}
+
+type bufOutput struct {
+	urlsets []*bytes.Buffer
+	index   bytes.Buffer
+}
+
+func (o *bufOutput) Urlset() io.Writer {
+	b := new(bytes.Buffer)
+	o.urlsets = append(o.urlsets, b)
+	return b
+}
+
+func (o *bufOutput) Index() io.Writer { return &o.index }
+
+func gunzip(t *testing.T, b *bytes.Buffer) string {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	defer gr.Close()
+	decoded, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("reading gzip stream: %v", err)
+	}
+	return string(decoded)
+}
+
+func TestGzipOutputRoundTrip(t *testing.T) {
+	entries := []*UrlEntry{
+		{Loc: "http://example.com/a"},
+		{Loc: "http://example.com/b"},
+		{Loc: "http://example.com/c"},
+	}
+	out := &bufOutput{}
+	gz := NewGzipOutput(out, gzip.DefaultCompression)
+
+	if err := WriteAll(gz, &sliceInput{entries: entries}); err != nil {
+		t.Fatalf("WriteAll: %v", err)
+	}
+	if len(out.urlsets) != 1 {
+		t.Fatalf("got %d urlset files, want 1", len(out.urlsets))
+	}
+
+	urlset := gunzip(t, out.urlsets[0])
+	for _, e := range entries {
+		if !strings.Contains(urlset, "<loc>"+e.Loc+"</loc>") {
+			t.Errorf("urlset missing <loc> for %q:\n%s", e.Loc, urlset)
+		}
+	}
+
+	index := gunzip(t, &out.index)
+	if !strings.Contains(index, "http://example.com/sitemap0.xml.gz") {
+		t.Errorf("index missing urlset URL:\n%s", index)
+	}
+}
+
+func TestGzipOutputMaxEntriesCap(t *testing.T) {
+	entries := make([]*UrlEntry, 5)
+	for i := range entries {
+		entries[i] = &UrlEntry{Loc: fmt.Sprintf("http://example.com/%d", i)}
+	}
+	out := &bufOutput{}
+	gz := NewGzipOutput(out, gzip.DefaultCompression)
+
+	opts := Options{MaxEntries: 2}
+	if err := WriteAllOptions(gz, &sliceInput{entries: entries}, opts); err != nil {
+		t.Fatalf("WriteAllOptions: %v", err)
+	}
+
+	// 5 entries capped at 2 per file must still produce 3 urlset files,
+	// even though each file is gzip-compressed: the cap applies to the
+	// uncompressed entry count, not to compressed byte size.
+	if len(out.urlsets) != 3 {
+		t.Fatalf("got %d urlset files, want 3", len(out.urlsets))
+	}
+
+	var total int
+	for _, b := range out.urlsets {
+		total += strings.Count(gunzip(t, b), "<url>")
+	}
+	if total != len(entries) {
+		t.Errorf("got %d total <url> entries across files, want %d", total, len(entries))
+	}
+}
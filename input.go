@@ -0,0 +1,18 @@
+package sitemap
+
+// Input supplies the stream of URL entries that WriteAll writes into urlset
+// files, and the public URL of each urlset file once it is known, for
+// inclusion in the sitemap index.
+type Input interface {
+	// HasNext reports whether there is at least one more entry to write.
+	// That entry may go into the current urlset file or the next one.
+	HasNext() bool
+
+	// Next returns the next entry and advances the input. It is only
+	// called when HasNext reports true.
+	Next() *UrlEntry
+
+	// GetUrlsetUrl returns the public URL of the i'th urlset file
+	// (0-based), used when building the sitemap index.
+	GetUrlsetUrl(i int) string
+}
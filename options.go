@@ -0,0 +1,41 @@
+package sitemap
+
+// Options configures the per-file limits WriteAllOptions enforces. The zero
+// value selects the default limits from the sitemaps.org spec.
+type Options struct {
+	// MaxEntries caps the number of <url> entries per urlset file. Zero
+	// selects the default, maxSitemapCap (50,000).
+	MaxEntries int
+
+	// MaxBytes caps the uncompressed size, in bytes, of a urlset file,
+	// including its XML header and footer. Zero selects the default,
+	// maxSitemapBytes (50MB, minus room for the footer).
+	MaxBytes int64
+
+	// Progress, if non-nil, is called back as urlset and index files are
+	// written. When opts is passed to WriteAllParallelOptions, Progress is
+	// called concurrently from every worker goroutine and must be safe for
+	// concurrent use; see the Progress doc comment.
+	Progress Progress
+
+	// Transform, if non-nil, is called with every entry before it is
+	// validated and written. It may mutate and return e, or return nil to
+	// drop the entry from the output entirely. When opts is passed to
+	// WriteAllParallelOptions, Transform is called concurrently from every
+	// worker goroutine and must be safe for concurrent use.
+	Transform func(e *UrlEntry) *UrlEntry
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = maxSitemapCap
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = maxSitemapBytes
+	}
+	return o
+}
+
+// maxSitemapBytes is the sitemaps.org 50MB uncompressed size limit per
+// urlset file, minus room for the closing </urlset> footer.
+var maxSitemapBytes = int64(50<<20 - len(urlsetFooter))
@@ -0,0 +1,16 @@
+package sitemap
+
+import "io"
+
+// Output supplies the writers that WriteAll serializes sitemap files to.
+// Implementations decide where each file goes, for example local files
+// named sitemap1.xml, sitemap2.xml, ... and sitemap.xml for the index.
+type Output interface {
+	// Urlset returns the writer for the next urlset file. It is called
+	// once per urlset file, in order, starting from the first.
+	Urlset() io.Writer
+
+	// Index returns the writer for the sitemap index file. It is called
+	// exactly once, after every urlset file has been written.
+	Index() io.Writer
+}
@@ -0,0 +1,115 @@
+package sitemap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// contiguousShardedInput splits entries into n contiguous, roughly equal
+// shards, the simplest ShardedInput implementation.
+type contiguousShardedInput struct {
+	entries []*UrlEntry
+}
+
+func (s *contiguousShardedInput) Shard(i, n int) Input {
+	size := len(s.entries) / n
+	start := i * size
+	end := start + size
+	if i == n-1 {
+		end = len(s.entries)
+	}
+	return &sliceInput{entries: s.entries[start:end]}
+}
+
+// parallelBufOutput collects the urlset buffers WriteAllParallelOptions
+// writes to, keyed by shard, plus the single index buffer. Urlset is
+// called concurrently from every worker goroutine, so access is guarded
+// by mu.
+type parallelBufOutput struct {
+	mu      sync.Mutex
+	urlsets map[int][]*bytes.Buffer
+	index   bytes.Buffer
+}
+
+func (o *parallelBufOutput) Urlset(shardID int) io.Writer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.urlsets == nil {
+		o.urlsets = make(map[int][]*bytes.Buffer)
+	}
+	b := new(bytes.Buffer)
+	o.urlsets[shardID] = append(o.urlsets[shardID], b)
+	return b
+}
+
+func (o *parallelBufOutput) Index() io.Writer { return &o.index }
+
+func TestWriteAllParallelMaxEntriesMultiFilePerShard(t *testing.T) {
+	const shards = 2
+	entries := make([]*UrlEntry, 8)
+	for i := range entries {
+		entries[i] = &UrlEntry{Loc: fmt.Sprintf("http://example.com/%d", i)}
+	}
+
+	out := &parallelBufOutput{}
+	opts := Options{MaxEntries: 2}
+	err := WriteAllParallelOptions(out, &contiguousShardedInput{entries: entries}, shards, opts)
+	if err != nil {
+		t.Fatalf("WriteAllParallelOptions: %v", err)
+	}
+
+	// 4 entries per shard capped at 2 per file must produce 2 files per
+	// shard, 4 files total.
+	var total, files int
+	for shardID := 0; shardID < shards; shardID++ {
+		bufs := out.urlsets[shardID]
+		files += len(bufs)
+		if len(bufs) != 2 {
+			t.Errorf("shard %d produced %d urlset files, want 2", shardID, len(bufs))
+		}
+		for _, b := range bufs {
+			total += strings.Count(b.String(), "<url>")
+		}
+	}
+	if files != 4 {
+		t.Errorf("got %d urlset files total, want 4", files)
+	}
+	if total != len(entries) {
+		t.Errorf("got %d total <url> entries, want %d", total, len(entries))
+	}
+	if got := strings.Count(out.index.String(), "<loc>"); got != files {
+		t.Errorf("index lists %d urlset files, want %d", got, files)
+	}
+}
+
+func TestWriteAllParallelErrorPropagation(t *testing.T) {
+	const shards = 2
+	entries := []*UrlEntry{
+		{Loc: "http://example.com/ok-0"},
+		{Loc: "http://example.com/ok-1"},
+		{Loc: ""}, // invalid: ErrInvalidLoc, lands in the 2nd shard
+		{Loc: "http://example.com/ok-3"},
+	}
+
+	out := &parallelBufOutput{}
+	err := WriteAllParallel(out, &contiguousShardedInput{entries: entries}, shards)
+	if err == nil {
+		t.Fatal("WriteAllParallel error = nil, want a validation error")
+	}
+
+	var sErr *Error
+	if !errors.As(err, &sErr) {
+		t.Fatalf("error %v is not a *sitemap.Error", err)
+	}
+	if sErr.Op != OpValidateEntry {
+		t.Errorf("Op = %q, want %q", sErr.Op, OpValidateEntry)
+	}
+	if !errors.Is(err, ErrInvalidLoc) {
+		t.Errorf("error %v does not wrap ErrInvalidLoc", err)
+	}
+}
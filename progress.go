@@ -0,0 +1,32 @@
+package sitemap
+
+// Progress receives callbacks as WriteAllOptions or WriteAllParallelOptions
+// make progress, for visibility into
+// long-running generations (millions of URLs, a slow database-backed
+// Input.Next()). Set it on Options.Progress; a nil Progress (the default)
+// disables the callbacks entirely. WriteAll and WriteAllContext take no
+// Options, so they never call a Progress.
+//
+// WriteAllParallelOptions calls OnEntry and OnFileComplete concurrently
+// from every worker goroutine, so an implementation used with it must be
+// safe for concurrent use (e.g. guard any shared state with a mutex, or
+// use atomic counters). OnIndexComplete is always called from a single
+// goroutine, after every shard has finished.
+type Progress interface {
+	// OnEntry is called once an entry has been written to the urlset file
+	// fileIndex, at position entryIndex within that file (both 0-based).
+	// shardID is 0 for WriteAllOptions, and the 0-based shard index for
+	// WriteAllParallelOptions; fileIndex and
+	// entryIndex are only unique within a given shardID, since each shard
+	// numbers its own files and entries independently.
+	OnEntry(shardID, fileIndex, entryIndex int, e *UrlEntry)
+
+	// OnFileComplete is called once a urlset file is finished, with the
+	// number of uncompressed bytes and URLs written to it. shardID is as
+	// described on OnEntry.
+	OnFileComplete(shardID, fileIndex int, bytesWritten int64, urls int)
+
+	// OnIndexComplete is called once the index file is finished, with the
+	// number of urlset files and uncompressed bytes it lists.
+	OnIndexComplete(nfiles int, bytesWritten int64)
+}
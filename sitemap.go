@@ -2,41 +2,100 @@ package sitemap
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"errors"
 	"io"
+	"strconv"
 	"time"
 )
 
-// WriteAll writes all files to the given output. Urlset files are written to
-// writers provided by o.Urlset(), the function will call it every time a new
-// file is to be written. The final index file is written to a writer provided
-// by o.Index().
-// The function aborts if any unexpected error occurs when writing.
+// Finisher is implemented by an Output whose writers need to be finalized
+// (flushed, closed, ...) once WriteAll is done writing to them, such as
+// GzipOutput's gzip.Writer. WriteAll calls Finish with the exact writer
+// previously returned from Urlset or Index once that file is complete.
+type Finisher interface {
+	Finish(w io.Writer) error
+}
+
+// WriteAll writes all files to the given output using the default limits.
+// It is equivalent to WriteAllOptions(o, in, Options{}).
 func WriteAll(o Output, in Input) error {
+	return writeAll(context.Background(), o, in, Options{})
+}
+
+// WriteAllOptions writes all files to the given output. Urlset files are
+// written to writers provided by o.Urlset(), the function will call it every
+// time a new file is to be written. The final index file is written to a
+// writer provided by o.Index(). opts controls the per-file entry count and
+// byte size limits; see Options.
+// The function aborts if any unexpected error occurs when writing.
+func WriteAllOptions(o Output, in Input, opts Options) error {
+	return writeAll(context.Background(), o, in, opts)
+}
+
+// WriteAllContext is like WriteAll, but checks ctx.Err() between entries and
+// between files, so a canceled context aborts promptly instead of running to
+// completion, for example when in is backed by a slow database query.
+func WriteAllContext(ctx context.Context, o Output, in Input) error {
+	return writeAll(ctx, o, in, Options{})
+}
+
+func writeAll(ctx context.Context, o Output, in Input, opts Options) error {
+	opts = opts.withDefaults()
+
 	var s sitemapWriter
 	var nfiles int
+	var pending *UrlEntry
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		w := o.Urlset()
+		var err error
+		pending, err = s.writeUrlsetFile(ctx, w, in, opts, pending, 0, nfiles)
 		nfiles++
-		err := s.writeUrlsetFile(o.Urlset(), in)
+		if ferr := finish(o, w); err == nil {
+			err = ferr
+		}
 		if err != nil && !errors.Is(err, errMaxCapReached{}) {
 			return err
 		}
 
 		if err == nil {
-			return s.writeIndexFile(o.Index(), in, nfiles)
+			w := o.Index()
+			err := s.writeIndexFile(ctx, w, in, nfiles, opts)
+			if ferr := finish(o, w); err == nil {
+				err = ferr
+			}
+			return err
 		}
 	}
 }
 
+// finish calls o.Finish(w) when o implements Finisher, and is a no-op
+// otherwise. o is typically an Output or a ParallelOutput.
+func finish(o any, w io.Writer) error {
+	if f, ok := o.(Finisher); ok {
+		return f.Finish(w)
+	}
+	return nil
+}
+
 type sitemapWriter struct {
 	// temporary buffer used to escape string values for XML
 	buf bytes.Buffer
+
+	// scratch buffer an entry is serialized into before being committed to
+	// the underlying writer, so its size can be checked against
+	// Options.MaxBytes before it is written
+	entryBuf bytes.Buffer
 }
 
 // writeIndexFile writes Sitemap index file for N files.
-func (s *sitemapWriter) writeIndexFile(w io.Writer, in Input, nfiles int) error {
-	abortWriter := abortWriter{underlying: w}
+func (s *sitemapWriter) writeIndexFile(ctx context.Context, w io.Writer, in Input, nfiles int, opts Options) error {
+	abortWriter := abortWriter{ctx: ctx, underlying: w}
 
 	_, _ = abortWriter.Write(indexHeader)
 	for i := 0; i < nfiles; i++ {
@@ -44,31 +103,99 @@ func (s *sitemapWriter) writeIndexFile(w io.Writer, in Input, nfiles int) error
 	}
 	_, _ = abortWriter.Write(indexFooter)
 
-	return abortWriter.firstErr
+	if abortWriter.firstErr != nil {
+		return &Error{Op: OpWriteIndex, FileIndex: -1, EntryIndex: -1, Err: abortWriter.firstErr}
+	}
+
+	if opts.Progress != nil {
+		opts.Progress.OnIndexComplete(nfiles, abortWriter.written)
+	}
+	return nil
 }
 
-// writeUrlsetFile writes a single Sitemap Urlset file for the first 50K entries
-// in the given input.
-func (s *sitemapWriter) writeUrlsetFile(w io.Writer, in Input) error {
-	abortWriter := abortWriter{underlying: w}
+// writeUrlsetFile writes a single Sitemap Urlset file, stopping once either
+// opts.MaxEntries entries or opts.MaxBytes uncompressed bytes have been
+// written. pending, if non-nil, is an entry read from a previous call that
+// did not fit in that file and is written first here.
+//
+// It returns the entry that did not fit in this file, to be passed as
+// pending to the next call, or nil if every entry in in was written.
+// shardID is 0 for the single-threaded WriteAll path, and the 0-based
+// shard index when called from WriteAllParallelOptions; it is only used
+// to identify the shard to opts.Progress, since fileIndex and the entry
+// index are only unique within a single shard.
+func (s *sitemapWriter) writeUrlsetFile(ctx context.Context, w io.Writer, in Input, opts Options, pending *UrlEntry, shardID, fileIndex int) (*UrlEntry, error) {
+	abortWriter := abortWriter{ctx: ctx, underlying: w}
 	var capErr error
+	bytesWritten := int64(len(urlsetHeader))
 
 	_, _ = abortWriter.Write(urlsetHeader)
-	for count := 0; in.HasNext(); count++ {
-		if count >= maxSitemapCap {
+
+	var written int
+	for pending != nil || in.HasNext() {
+		if err := ctx.Err(); err != nil {
+			return pending, err
+		}
+
+		e := pending
+		pending = nil
+		if e == nil {
+			e = in.Next()
+			if opts.Transform != nil {
+				e = opts.Transform(e)
+				if e == nil {
+					continue
+				}
+			}
+		}
+
+		if written >= opts.MaxEntries {
 			capErr = errMaxCapReached{}
+			pending = e
 			break
 		}
 
-		s.writeXmlUrlEntry(&abortWriter, in.Next())
+		if err := e.Validate(); err != nil {
+			return nil, &Error{Op: OpValidateEntry, FileIndex: fileIndex, EntryIndex: written, Err: err}
+		}
+
+		// Serialize into a scratch buffer first so its size is known before
+		// it is committed to the underlying writer: in.Next() has no way to
+		// put the entry back, so an entry that doesn't fit is instead
+		// carried over as pending for the next urlset file.
+		s.entryBuf.Reset()
+		s.writeXmlUrlEntry(&s.entryBuf, e)
+
+		if written > 0 && bytesWritten+int64(s.entryBuf.Len())+int64(len(urlsetFooter)) > opts.MaxBytes {
+			capErr = errMaxCapReached{}
+			pending = e
+			break
+		}
+
+		n, _ := abortWriter.Write(s.entryBuf.Bytes())
+		bytesWritten += int64(n)
+
+		if opts.Progress != nil {
+			opts.Progress.OnEntry(shardID, fileIndex, written, e)
+		}
+		written++
 	}
+
 	_, _ = abortWriter.Write(urlsetFooter)
 
 	if abortWriter.firstErr != nil {
-		return abortWriter.firstErr
+		return pending, &Error{Op: OpWriteUrlset, FileIndex: fileIndex, EntryIndex: -1, Err: abortWriter.firstErr}
 	}
 
-	return capErr
+	if opts.Progress != nil {
+		opts.Progress.OnFileComplete(shardID, fileIndex, abortWriter.written, written)
+	}
+
+	if capErr != nil {
+		return pending, &Error{Op: OpWriteUrlset, FileIndex: fileIndex, EntryIndex: -1, Err: capErr}
+	}
+
+	return pending, nil
 }
 
 func (s *sitemapWriter) writeXmlUrlEntry(w io.Writer, e *UrlEntry) {
@@ -81,16 +208,124 @@ func (s *sitemapWriter) writeXmlUrlEntry(w io.Writer, e *UrlEntry) {
 		s.writeXmlTime(w, e.LastMod)
 		_, _ = w.Write(tagLastmodClose)
 	}
-	if len(e.Images) > 0 {
-		for i := range e.Images {
-			_, _ = w.Write(tagImageOpen)
-			s.writeXmlString(w, e.Images[i])
-			_, _ = w.Write(tagImageClose)
-		}
+	if e.ChangeFreq != "" {
+		_, _ = w.Write(tagChangefreqOpen)
+		_, _ = w.Write([]byte(e.ChangeFreq))
+		_, _ = w.Write(tagChangefreqClose)
+	}
+	if e.Priority != 0 {
+		_, _ = w.Write(tagPriorityOpen)
+		_, _ = w.Write(strconv.AppendFloat(nil, e.Priority, 'f', -1, 64))
+		_, _ = w.Write(tagPriorityClose)
+	}
+	for i := range e.Images {
+		s.writeXmlImage(w, &e.Images[i])
+	}
+	for i := range e.Videos {
+		s.writeXmlVideo(w, &e.Videos[i])
+	}
+	for i := range e.HrefLangs {
+		s.writeXmlHrefLang(w, &e.HrefLangs[i])
 	}
 	_, _ = w.Write(tagUrlClose)
 }
 
+func (s *sitemapWriter) writeXmlImage(w io.Writer, img *Image) {
+	_, _ = w.Write(tagImageOpen)
+	_, _ = w.Write(tagImageLocOpen)
+	s.writeXmlString(w, img.Loc)
+	_, _ = w.Write(tagImageLocClose)
+	if img.Title != "" {
+		_, _ = w.Write(tagImageTitleOpen)
+		s.writeXmlString(w, img.Title)
+		_, _ = w.Write(tagImageTitleClose)
+	}
+	if img.Caption != "" {
+		_, _ = w.Write(tagImageCaptionOpen)
+		s.writeXmlString(w, img.Caption)
+		_, _ = w.Write(tagImageCaptionClose)
+	}
+	if img.License != "" {
+		_, _ = w.Write(tagImageLicenseOpen)
+		s.writeXmlString(w, img.License)
+		_, _ = w.Write(tagImageLicenseClose)
+	}
+	if img.GeoLocation != "" {
+		_, _ = w.Write(tagImageGeoLocationOpen)
+		s.writeXmlString(w, img.GeoLocation)
+		_, _ = w.Write(tagImageGeoLocationClose)
+	}
+	_, _ = w.Write(tagImageClose)
+}
+
+func (s *sitemapWriter) writeXmlVideo(w io.Writer, v *Video) {
+	_, _ = w.Write(tagVideoOpen)
+
+	_, _ = w.Write(tagVideoThumbnailLocOpen)
+	s.writeXmlString(w, v.ThumbnailLoc)
+	_, _ = w.Write(tagVideoThumbnailLocClose)
+
+	_, _ = w.Write(tagVideoTitleOpen)
+	s.writeXmlString(w, v.Title)
+	_, _ = w.Write(tagVideoTitleClose)
+
+	_, _ = w.Write(tagVideoDescriptionOpen)
+	s.writeXmlString(w, v.Description)
+	_, _ = w.Write(tagVideoDescriptionClose)
+
+	if v.ContentLoc != "" {
+		_, _ = w.Write(tagVideoContentLocOpen)
+		s.writeXmlString(w, v.ContentLoc)
+		_, _ = w.Write(tagVideoContentLocClose)
+	}
+	if v.PlayerLoc != "" {
+		_, _ = w.Write(tagVideoPlayerLocOpen)
+		s.writeXmlString(w, v.PlayerLoc)
+		_, _ = w.Write(tagVideoPlayerLocClose)
+	}
+	if v.Duration != 0 {
+		_, _ = w.Write(tagVideoDurationOpen)
+		_, _ = w.Write(strconv.AppendInt(nil, int64(v.Duration/time.Second), 10))
+		_, _ = w.Write(tagVideoDurationClose)
+	}
+	if !v.PublicationDate.IsZero() {
+		_, _ = w.Write(tagVideoPublicationDateOpen)
+		s.writeXmlTime(w, v.PublicationDate)
+		_, _ = w.Write(tagVideoPublicationDateClose)
+	}
+	if v.FamilyFriendly != nil {
+		_, _ = w.Write(tagVideoFamilyFriendlyOpen)
+		if *v.FamilyFriendly {
+			_, _ = w.Write(yesBytes)
+		} else {
+			_, _ = w.Write(noBytes)
+		}
+		_, _ = w.Write(tagVideoFamilyFriendlyClose)
+	}
+	if v.Restriction != nil {
+		_, _ = w.Write(tagVideoRestrictionOpen)
+		_, _ = w.Write([]byte(v.Restriction.Relationship))
+		_, _ = w.Write(tagVideoRestrictionAttrEnd)
+		for i, c := range v.Restriction.Countries {
+			if i > 0 {
+				_, _ = w.Write(spaceBytes)
+			}
+			s.writeXmlString(w, c)
+		}
+		_, _ = w.Write(tagVideoRestrictionClose)
+	}
+
+	_, _ = w.Write(tagVideoClose)
+}
+
+func (s *sitemapWriter) writeXmlHrefLang(w io.Writer, h *HrefLang) {
+	_, _ = w.Write(tagHrefLangOpen)
+	s.writeXmlString(w, h.Lang)
+	_, _ = w.Write(tagHrefLangHref)
+	s.writeXmlString(w, h.Href)
+	_, _ = w.Write(tagHrefLangClose)
+}
+
 func (s *sitemapWriter) writeXmlUrlLoc(w io.Writer, loc string) {
 	_, _ = w.Write(tagUrlOpen)
 	_, _ = w.Write(tagLocOpen)
@@ -125,8 +360,16 @@ var (
 	)
 	indexFooter = []byte("</sitemapindex>")
 
+	// video:/xhtml: are declared unconditionally, even for files with no
+	// video or hreflang entries: an earlier version only declared them
+	// on demand, but that required buffering an entire file's entries
+	// before the header (and therefore its namespace set) could be
+	// written, which could grow a pooled sitemapWriter's buffer toward
+	// Options.MaxBytes (default 50MB) per file, multiplied by worker
+	// count under WriteAllParallelOptions. The roughly 100 extra header
+	// bytes this costs per file are negligible next to that cap.
 	urlsetHeader = []byte(xml.Header +
-		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">` +
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1" xmlns:video="http://www.google.com/schemas/sitemap-video/1.1" xmlns:xhtml="http://www.w3.org/1999/xhtml">` +
 		"\n",
 	)
 	urlsetFooter = []byte(`</urlset>`)
@@ -137,23 +380,76 @@ var (
 	tagLocClose     = []byte("</loc>\n")
 	tagLastmodOpen  = []byte("    <lastmod>")
 	tagLastmodClose = []byte("</lastmod>\n")
-	tagImageOpen    = []byte("    <image:image>\n      <image:loc>")
-	tagImageClose   = []byte("</image:loc>\n    </image:image>\n")
+
+	tagChangefreqOpen  = []byte("    <changefreq>")
+	tagChangefreqClose = []byte("</changefreq>\n")
+	tagPriorityOpen    = []byte("    <priority>")
+	tagPriorityClose   = []byte("</priority>\n")
+
+	tagImageOpen             = []byte("    <image:image>\n")
+	tagImageClose            = []byte("    </image:image>\n")
+	tagImageLocOpen          = []byte("      <image:loc>")
+	tagImageLocClose         = []byte("</image:loc>\n")
+	tagImageTitleOpen        = []byte("      <image:title>")
+	tagImageTitleClose       = []byte("</image:title>\n")
+	tagImageCaptionOpen      = []byte("      <image:caption>")
+	tagImageCaptionClose     = []byte("</image:caption>\n")
+	tagImageLicenseOpen      = []byte("      <image:license>")
+	tagImageLicenseClose     = []byte("</image:license>\n")
+	tagImageGeoLocationOpen  = []byte("      <image:geo_location>")
+	tagImageGeoLocationClose = []byte("</image:geo_location>\n")
+
+	tagVideoOpen                 = []byte("    <video:video>\n")
+	tagVideoClose                = []byte("    </video:video>\n")
+	tagVideoThumbnailLocOpen     = []byte("      <video:thumbnail_loc>")
+	tagVideoThumbnailLocClose    = []byte("</video:thumbnail_loc>\n")
+	tagVideoTitleOpen            = []byte("      <video:title>")
+	tagVideoTitleClose           = []byte("</video:title>\n")
+	tagVideoDescriptionOpen      = []byte("      <video:description>")
+	tagVideoDescriptionClose     = []byte("</video:description>\n")
+	tagVideoContentLocOpen       = []byte("      <video:content_loc>")
+	tagVideoContentLocClose      = []byte("</video:content_loc>\n")
+	tagVideoPlayerLocOpen        = []byte("      <video:player_loc>")
+	tagVideoPlayerLocClose       = []byte("</video:player_loc>\n")
+	tagVideoDurationOpen         = []byte("      <video:duration>")
+	tagVideoDurationClose        = []byte("</video:duration>\n")
+	tagVideoPublicationDateOpen  = []byte("      <video:publication_date>")
+	tagVideoPublicationDateClose = []byte("</video:publication_date>\n")
+	tagVideoFamilyFriendlyOpen   = []byte("      <video:family_friendly>")
+	tagVideoFamilyFriendlyClose  = []byte("</video:family_friendly>\n")
+	tagVideoRestrictionOpen      = []byte(`      <video:restriction relationship="`)
+	tagVideoRestrictionAttrEnd   = []byte(`">`)
+	tagVideoRestrictionClose     = []byte("</video:restriction>\n")
+
+	tagHrefLangOpen  = []byte(`    <xhtml:link rel="alternate" hreflang="`)
+	tagHrefLangHref  = []byte(`" href="`)
+	tagHrefLangClose = []byte(`"/>` + "\n")
+
+	yesBytes   = []byte("yes")
+	noBytes    = []byte("no")
+	spaceBytes = []byte(" ")
 )
 
 var minDate = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
 
 type abortWriter struct {
+	ctx        context.Context
 	underlying io.Writer
 	firstErr   error
+	written    int64
 }
 
 func (w *abortWriter) Write(p []byte) (n int, err error) {
 	if w.firstErr != nil {
 		return 0, w.firstErr
 	}
+	if err := w.ctx.Err(); err != nil {
+		w.firstErr = err
+		return 0, err
+	}
 
 	n, err = w.underlying.Write(p)
+	w.written += int64(n)
 	if err != nil {
 		w.firstErr = err
 	}
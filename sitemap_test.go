@@ -0,0 +1,67 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteAllMaxBytesCap(t *testing.T) {
+	// Each entry serializes to a fixed-size <url> block; pick MaxBytes so
+	// only 2 of these fit in the first file, forcing the 3rd entry to
+	// carry over to a second file.
+	entries := []*UrlEntry{
+		{Loc: "http://example.com/aaaaaaaaaa"},
+		{Loc: "http://example.com/bbbbbbbbbb"},
+		{Loc: "http://example.com/cccccccccc"},
+	}
+	entrySize := len(entryXML(entries[0]))
+	opts := Options{
+		MaxBytes: int64(len(urlsetHeader)+len(urlsetFooter)) + int64(entrySize)*2,
+	}
+
+	out := &bufOutput{}
+	if err := WriteAllOptions(out, &sliceInput{entries: entries}, opts); err != nil {
+		t.Fatalf("WriteAllOptions: %v", err)
+	}
+
+	if len(out.urlsets) != 2 {
+		t.Fatalf("got %d urlset files, want 2", len(out.urlsets))
+	}
+	if got := strings.Count(out.urlsets[0].String(), "<url>"); got != 2 {
+		t.Errorf("first file has %d <url> entries, want 2:\n%s", got, out.urlsets[0].String())
+	}
+	if got := strings.Count(out.urlsets[1].String(), "<url>"); got != 1 {
+		t.Errorf("second file has %d <url> entries, want 1:\n%s", got, out.urlsets[1].String())
+	}
+	if !strings.Contains(out.urlsets[1].String(), entries[2].Loc) {
+		t.Errorf("second file missing carried-over entry %q:\n%s", entries[2].Loc, out.urlsets[1].String())
+	}
+}
+
+func TestWriteAllMaxBytesCapAlwaysWritesOneEntry(t *testing.T) {
+	// MaxBytes smaller than a single entry must not loop forever or drop
+	// the entry: the first entry in a file is always written regardless
+	// of MaxBytes.
+	entries := []*UrlEntry{{Loc: "http://example.com/a-very-long-url-indeed"}}
+	opts := Options{MaxBytes: 1}
+
+	out := &bufOutput{}
+	if err := WriteAllOptions(out, &sliceInput{entries: entries}, opts); err != nil {
+		t.Fatalf("WriteAllOptions: %v", err)
+	}
+	if len(out.urlsets) != 1 {
+		t.Fatalf("got %d urlset files, want 1", len(out.urlsets))
+	}
+	if !strings.Contains(out.urlsets[0].String(), entries[0].Loc) {
+		t.Errorf("file missing entry %q:\n%s", entries[0].Loc, out.urlsets[0].String())
+	}
+}
+
+// entryXML renders e the same way writeUrlsetFile does, for computing an
+// exact MaxBytes boundary in tests.
+func entryXML(e *UrlEntry) string {
+	var s sitemapWriter
+	var buf strings.Builder
+	s.writeXmlUrlEntry(&buf, e)
+	return buf.String()
+}
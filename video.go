@@ -0,0 +1,75 @@
+package sitemap
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors returned by Video.validate.
+var (
+	ErrInvalidVideo            = errors.New("sitemap: video requires thumbnail_loc, title, description and one of content_loc/player_loc")
+	ErrInvalidVideoRestriction = errors.New("sitemap: video restriction relationship must be \"allow\" or \"deny\"")
+)
+
+// VideoRestriction limits which countries a Video is viewable in.
+type VideoRestriction struct {
+	// Countries are ISO 3166 country codes, e.g. "US", "CA".
+	Countries []string
+
+	// Relationship is "allow" to restrict viewing to Countries, or "deny"
+	// to block viewing in Countries.
+	Relationship string
+}
+
+func (r *VideoRestriction) valid() bool {
+	return r.Relationship == "allow" || r.Relationship == "deny"
+}
+
+// Video is a single <video:video> entry associated with a UrlEntry.
+type Video struct {
+	// ThumbnailLoc is the URL of the video's thumbnail image. Required.
+	ThumbnailLoc string
+
+	// Title is the title of the video. Required.
+	Title string
+
+	// Description is a description of the video. Required.
+	Description string
+
+	// ContentLoc is the URL pointing directly to the video file. Either
+	// ContentLoc or PlayerLoc is required.
+	ContentLoc string
+
+	// PlayerLoc is the URL of a player for the video, e.g. an embed URL.
+	// Either ContentLoc or PlayerLoc is required.
+	PlayerLoc string
+
+	// Duration is the duration of the video. Zero omits the tag.
+	Duration time.Duration
+
+	// PublicationDate is when the video was first published. Zero value
+	// omits the tag.
+	PublicationDate time.Time
+
+	// FamilyFriendly indicates whether the video is appropriate for all
+	// audiences. Nil omits the tag, letting crawlers apply their default
+	// of true.
+	FamilyFriendly *bool
+
+	// Restriction limits which countries the video is viewable in. Nil
+	// omits the tag.
+	Restriction *VideoRestriction
+}
+
+func (v *Video) validate() error {
+	if v.ThumbnailLoc == "" || v.Title == "" || v.Description == "" {
+		return ErrInvalidVideo
+	}
+	if v.ContentLoc == "" && v.PlayerLoc == "" {
+		return ErrInvalidVideo
+	}
+	if v.Restriction != nil && !v.Restriction.valid() {
+		return ErrInvalidVideoRestriction
+	}
+	return nil
+}